@@ -0,0 +1,112 @@
+package router
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RouteContext carries everything about the current navigation that a
+// HandlerCtx might need: the matched path params, the parsed query string,
+// and the URL fragment, alongside the path that was actually matched.
+type RouteContext struct {
+	Params   map[string]string
+	Query    url.Values
+	Fragment string
+	RawPath  string
+}
+
+// HandlerCtx is like Handler, but receives the full RouteContext instead
+// of just the path params. Register one with HandleFuncCtx when a route
+// needs the query string or fragment, e.g. to handle "/search?q=foo&page=2".
+type HandlerCtx func(ctx *RouteContext)
+
+// MiddlewareCtx is the HandlerCtx analogue of Middleware: it wraps a
+// HandlerCtx to add behavior with access to the full RouteContext,
+// including the parsed query string and fragment, instead of just params.
+type MiddlewareCtx func(HandlerCtx) HandlerCtx
+
+// UseCtx registers one or more MiddlewareCtx functions to run, in order,
+// around every HandlerCtx route's handler. It runs after the plain
+// Middleware registered with Use, which also applies to HandlerCtx routes
+// (see chainCtx), so a single auth or logging middleware registered with
+// Use covers both Handler and HandlerCtx routes.
+func (r *Router) UseCtx(mw ...MiddlewareCtx) {
+	r.ctxMiddleware = append(r.ctxMiddleware, mw...)
+}
+
+// HandleFuncCtx is like HandleFunc, but registers a HandlerCtx, which
+// receives the full RouteContext (params, query, and fragment) instead of
+// just the path params.
+func (r *Router) HandleFuncCtx(path string, handler HandlerCtx) {
+	r.HandleCtx(path, handler)
+}
+
+// HandleCtx is like HandleFuncCtx, but additionally accepts middleware
+// which is applied only to this route, after the middleware registered
+// with Use/UseCtx on r and any of r's ancestors (see Subrouter).
+func (r *Router) HandleCtx(path string, handler HandlerCtx, mw ...MiddlewareCtx) {
+	route := newRoute(r.fullPath(path), nil)
+	route.ctxHandler = handler
+	route.ctxMiddleware = append(r.ancestorCtxMiddleware(), mw...)
+	r.register(route)
+}
+
+// asCtxMiddleware adapts a Handler-based Middleware so it can wrap a
+// HandlerCtx. The Middleware itself still only sees path params, but this
+// lets a single Middleware registered with Use run ahead of both Handler
+// and HandlerCtx routes; short-circuiting still works, since next is only
+// invoked if the adapted Handler calls through to it.
+func asCtxMiddleware(mw Middleware) MiddlewareCtx {
+	return func(next HandlerCtx) HandlerCtx {
+		return func(ctx *RouteContext) {
+			mw(func(params map[string]string) {
+				next(ctx)
+			})(ctx.Params)
+		}
+	}
+}
+
+// chainCtx composes the router's global middleware (both Use and UseCtx)
+// and route's own middleware around route.ctxHandler. Middleware from Use
+// runs outermost, then MiddlewareCtx from UseCtx, then route's own; within
+// each group the first registered runs outermost.
+func (r *Router) chainCtx(route *route) HandlerCtx {
+	handler := route.ctxHandler
+	for i := len(route.ctxMiddleware) - 1; i >= 0; i-- {
+		handler = route.ctxMiddleware[i](handler)
+	}
+	for i := len(r.ctxMiddleware) - 1; i >= 0; i-- {
+		handler = r.ctxMiddleware[i](handler)
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = asCtxMiddleware(r.middleware[i])(handler)
+	}
+	return handler
+}
+
+// splitLocation splits a raw location of the form "/path?query#fragment"
+// into its three parts. query and fragment are returned without their
+// leading "?" or "#".
+func splitLocation(raw string) (path, query, fragment string) {
+	path = raw
+	if i := strings.Index(path, "#"); i != -1 {
+		fragment = path[i+1:]
+		path = path[:i]
+	}
+	if i := strings.Index(path, "?"); i != -1 {
+		query = path[i+1:]
+		path = path[:i]
+	}
+	return path, query, fragment
+}
+
+// parseQuery decodes a raw query string, returning empty url.Values if it
+// fails to parse rather than propagating the error to callers that just
+// want to read query params.
+func parseQuery(raw string) url.Values {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return url.Values{}
+	}
+	return values
+}
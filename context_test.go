@@ -0,0 +1,53 @@
+package router
+
+import "testing"
+
+// TestHandleCtxRunsGlobalMiddleware pins that HandlerCtx routes still run
+// through the plain Middleware registered with Use (not just MiddlewareCtx
+// registered with UseCtx), so a single auth/logging middleware covers both
+// Handler and HandlerCtx routes.
+func TestHandleCtxRunsGlobalMiddleware(t *testing.T) {
+	r := New()
+	var order []string
+
+	r.Use(func(next Handler) Handler {
+		return func(params map[string]string) {
+			order = append(order, "global")
+			next(params)
+		}
+	})
+	r.UseCtx(func(next HandlerCtx) HandlerCtx {
+		return func(ctx *RouteContext) {
+			order = append(order, "global-ctx")
+			next(ctx)
+		}
+	})
+	r.HandleCtx("/search", func(ctx *RouteContext) {
+		order = append(order, "handler")
+	}, func(next HandlerCtx) HandlerCtx {
+		return func(ctx *RouteContext) {
+			order = append(order, "route")
+			next(ctx)
+		}
+	})
+
+	route, tokens := r.findBestRoute("/search")
+	if route == nil {
+		t.Fatal("expected /search to match")
+	}
+	params := map[string]string{}
+	for i, tok := range tokens {
+		params[route.paramNames[i]] = tok
+	}
+	r.chainCtx(route)(&RouteContext{Params: params, Query: parseQuery("q=foo")})
+
+	want := []string{"global", "global-ctx", "route", "handler"}
+	if len(order) != len(want) {
+		t.Fatalf("got order %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got order %v, want %v", order, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package router
+
+// CanNavigate reports whether any registered route matches path, ignoring
+// any query string or fragment it may contain. It does not trigger
+// navigation; it's useful for deciding whether to render a link as active
+// before calling Navigate, or before triggering navigation programmatically.
+func (r *Router) CanNavigate(path string) bool {
+	cleanPath, _, _ := splitLocation(path)
+	bestRoute, _ := r.findBestRoute(cleanPath)
+	return bestRoute != nil
+}
+
+// allowNavigate reports whether a transition to raw should proceed. If
+// BeforeNavigate is unset, every transition is allowed.
+func (r *Router) allowNavigate(raw string) bool {
+	if r.BeforeNavigate == nil {
+		return true
+	}
+	to, _, _ := splitLocation(raw)
+	return r.BeforeNavigate(r.currentPath, to)
+}
+
+// fireAfterNavigate calls AfterNavigate, if set.
+func (r *Router) fireAfterNavigate(path string) {
+	if r.AfterNavigate != nil {
+		r.AfterNavigate(path)
+	}
+}
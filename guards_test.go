@@ -0,0 +1,67 @@
+package router
+
+import "testing"
+
+// TestCanNavigate pins the happy path (a registered route matches) and the
+// edge case (no route matches, and any query string/fragment is ignored).
+func TestCanNavigate(t *testing.T) {
+	r := New()
+	r.HandleFunc("/users/{id}", func(map[string]string) {})
+
+	if !r.CanNavigate("/users/42?tab=profile#top") {
+		t.Fatal("expected /users/42 to match, ignoring query and fragment")
+	}
+	if r.CanNavigate("/nope") {
+		t.Fatal("expected /nope not to match")
+	}
+}
+
+// TestAllowNavigate pins that allowNavigate defers to BeforeNavigate with
+// the router's currentPath as "from", and that returning false blocks the
+// transition. Navigate and watchHash both rely on this to decide whether a
+// transition may proceed before touching window.location (see router.go).
+func TestAllowNavigate(t *testing.T) {
+	r := New()
+	r.currentPath = "/home"
+
+	var gotFrom, gotTo string
+	r.BeforeNavigate = func(from, to string) bool {
+		gotFrom, gotTo = from, to
+		return to != "/blocked"
+	}
+
+	if !r.allowNavigate("/next") {
+		t.Fatal("expected /next to be allowed")
+	}
+	if gotFrom != "/home" || gotTo != "/next" {
+		t.Fatalf("got from=%q to=%q, want from=/home to=/next", gotFrom, gotTo)
+	}
+	if r.allowNavigate("/blocked") {
+		t.Fatal("expected /blocked to be cancelled")
+	}
+}
+
+// TestAllowNavigateNoBeforeNavigate is the edge case where BeforeNavigate
+// is unset: every transition should be allowed.
+func TestAllowNavigateNoBeforeNavigate(t *testing.T) {
+	r := New()
+	if !r.allowNavigate("/anywhere") {
+		t.Fatal("expected navigation to be allowed when BeforeNavigate is unset")
+	}
+}
+
+// TestPathChangedFiresAfterNavigate pins that pathChanged calls
+// AfterNavigate with the matched path (stripped of query/fragment) once
+// dispatch completes, whether or not a route matched.
+func TestPathChangedFiresAfterNavigate(t *testing.T) {
+	r := New()
+	r.HandleFunc("/home", func(map[string]string) {})
+
+	var got string
+	r.AfterNavigate = func(path string) { got = path }
+
+	r.pathChanged("/home?x=1#frag")
+	if got != "/home" {
+		t.Fatalf("got AfterNavigate path %q, want /home", got)
+	}
+}
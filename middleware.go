@@ -0,0 +1,38 @@
+package router
+
+// Middleware wraps a Handler to add cross-cutting behavior such as
+// authentication, logging, analytics pageviews, or transition guards.
+// A Middleware may choose not to call the Handler it wraps, which lets
+// it short-circuit navigation entirely, e.g. an auth middleware can call
+// Navigate("/login") instead of invoking the downstream handler.
+type Middleware func(Handler) Handler
+
+// Use registers one or more Middleware functions to run, in order, ahead
+// of every route's Handler. Middleware registered with Use always runs
+// before any per-route middleware passed to Handle.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// Handle is like HandleFunc, but additionally accepts middleware which is
+// applied only to this route, after the middleware registered on r and any
+// of r's ancestors (see Subrouter).
+func (r *Router) Handle(path string, handler Handler, mw ...Middleware) {
+	route := newRoute(r.fullPath(path), handler)
+	route.middleware = append(r.ancestorMiddleware(), mw...)
+	r.register(route)
+}
+
+// chain composes the router's global middleware and route's own
+// middleware around route.handler, so that the first Middleware
+// registered with Use runs outermost.
+func (r *Router) chain(route *route) Handler {
+	handler := route.handler
+	for i := len(route.middleware) - 1; i >= 0; i-- {
+		handler = route.middleware[i](handler)
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler
+}
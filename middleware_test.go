@@ -0,0 +1,87 @@
+package router
+
+import "testing"
+
+// TestUseHandleOrdering pins that middleware registered on the root router
+// with Use runs, in registration order, ahead of per-route middleware
+// passed to Handle, which itself runs ahead of the route's own Handler.
+// TestMiddlewareOrdering (subrouter_test.go) covers the same composition
+// with a Subrouter layered in; this covers the plain root-only case.
+func TestUseHandleOrdering(t *testing.T) {
+	r := New()
+	var order []string
+	track := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(params map[string]string) {
+				order = append(order, name)
+				next(params)
+			}
+		}
+	}
+
+	r.Use(track("global1"))
+	r.Use(track("global2"))
+	r.Handle("/x", func(params map[string]string) {
+		order = append(order, "handler")
+	}, track("route"))
+
+	route, tokens := r.findBestRoute("/x")
+	if route == nil {
+		t.Fatal("expected /x to match")
+	}
+	params := map[string]string{}
+	for i, tok := range tokens {
+		params[route.paramNames[i]] = tok
+	}
+	r.chain(route)(params)
+
+	want := []string{"global1", "global2", "route", "handler"}
+	if len(order) != len(want) {
+		t.Fatalf("got order %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got order %v, want %v", order, want)
+		}
+	}
+}
+
+// TestMiddlewareShortCircuit is the edge case: a Middleware that never
+// calls next should stop the chain, so neither later middleware nor the
+// route's Handler runs.
+func TestMiddlewareShortCircuit(t *testing.T) {
+	r := New()
+	var laterMiddlewareRan, handlerRan bool
+
+	r.Use(func(next Handler) Handler {
+		return func(params map[string]string) {
+			// Deliberately never call next.
+		}
+	})
+	r.Use(func(next Handler) Handler {
+		return func(params map[string]string) {
+			laterMiddlewareRan = true
+			next(params)
+		}
+	})
+	r.Handle("/x", func(params map[string]string) {
+		handlerRan = true
+	})
+
+	route, tokens := r.findBestRoute("/x")
+	if route == nil {
+		t.Fatal("expected /x to match")
+	}
+	params := map[string]string{}
+	for i, tok := range tokens {
+		params[route.paramNames[i]] = tok
+	}
+	r.chain(route)(params)
+
+	if laterMiddlewareRan {
+		t.Fatal("expected later middleware to be skipped by the short-circuit")
+	}
+	if handlerRan {
+		t.Fatal("expected the route handler to be skipped by the short-circuit")
+	}
+}
@@ -0,0 +1,55 @@
+package router
+
+import (
+	"errors"
+	"strings"
+)
+
+// HandleFuncNamed is like HandleFunc, but also assigns name to the route
+// so that it can later be looked up with Router.URL. Names must be unique;
+// if a route with the same name already exists it will simply be
+// shadowed by the newer one when generating URLs.
+func (r *Router) HandleFuncNamed(name, path string, handler Handler) {
+	route := newRoute(r.fullPath(path), handler)
+	route.name = name
+	route.middleware = r.ancestorMiddleware()
+	r.register(route)
+}
+
+// URL builds the path for the named route, substituting each "{param}"
+// placeholder in the route's original path template with the value from
+// params. It returns an error if no route with the given name has been
+// registered, or if params is missing a value the route requires.
+func (r *Router) URL(name string, params map[string]string) (string, error) {
+	route := r.routeByName(name)
+	if route == nil {
+		return "", errors.New("router: no route named " + name)
+	}
+	strs := removeEmptyStrings(strings.Split(route.path, "/"))
+	segments := make([]string, len(strs))
+	for i, str := range strs {
+		if str[0] == '{' && str[len(str)-1] == '}' {
+			paramName, _ := parseParamToken(str[1 : len(str)-1])
+			value, ok := params[paramName]
+			if !ok {
+				return "", errors.New("router: missing value for param " + paramName + " in route " + name)
+			}
+			segments[i] = value
+		} else {
+			segments[i] = str
+		}
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// routeByName returns the most recently registered route with the given
+// name, or nil if no such route exists.
+func (r *Router) routeByName(name string) *route {
+	var found *route
+	for _, route := range r.root().routes {
+		if route.name == name {
+			found = route
+		}
+	}
+	return found
+}
@@ -0,0 +1,33 @@
+package router
+
+import "testing"
+
+// TestRouterURL pins the happy path (every param supplied builds the
+// expected path) alongside the two error edge cases URL documents: a
+// missing required param, and an unknown route name.
+func TestRouterURL(t *testing.T) {
+	r := New()
+	r.HandleFuncNamed("user", "/users/{id}", func(map[string]string) {})
+
+	t.Run("happy path", func(t *testing.T) {
+		got, err := r.URL("user", map[string]string{"id": "42"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "/users/42" {
+			t.Fatalf("got %q, want /users/42", got)
+		}
+	})
+
+	t.Run("missing param", func(t *testing.T) {
+		if _, err := r.URL("user", map[string]string{}); err == nil {
+			t.Fatal("expected an error for a missing required param")
+		}
+	})
+
+	t.Run("unknown route name", func(t *testing.T) {
+		if _, err := r.URL("nope", nil); err == nil {
+			t.Fatal("expected an error for an unknown route name")
+		}
+	})
+}
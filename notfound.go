@@ -0,0 +1,16 @@
+package router
+
+import "github.com/gopherjs/gopherjs/js"
+
+// notFound is called by pathChanged when no route matches path. It defers
+// to r.NotFoundHandler if one is set, passing the attempted path as
+// params["_path"] so an SPA can render a 404 view instead of the router
+// killing the whole app with a fatal error. If NotFoundHandler is unset,
+// it just logs a console warning.
+func (r *Router) notFound(path string) {
+	if r.NotFoundHandler != nil {
+		r.NotFoundHandler(map[string]string{"_path": path})
+		return
+	}
+	js.Global.Get("console").Call("warn", "router: no route found for "+path)
+}
@@ -0,0 +1,31 @@
+package router
+
+import "testing"
+
+// TestNotFoundHandler pins the happy path (a custom NotFoundHandler is
+// invoked with the attempted path under "_path" instead of the router
+// falling back to its default console warning) and the edge case that the
+// path it receives has already had its query string and fragment stripped,
+// same as any other route's params.
+//
+// The default (no NotFoundHandler) fallback logs via js.Global, which
+// requires a real browser/GopherJS environment and so isn't exercised
+// here; see notfound.go.
+func TestNotFoundHandler(t *testing.T) {
+	r := New()
+
+	var got string
+	r.NotFoundHandler = func(params map[string]string) {
+		got = params["_path"]
+	}
+
+	r.pathChanged("/missing")
+	if got != "/missing" {
+		t.Fatalf("got _path %q, want /missing", got)
+	}
+
+	r.pathChanged("/also-missing?x=1#frag")
+	if got != "/also-missing" {
+		t.Fatalf("got _path %q, want /also-missing with query/fragment stripped", got)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"github.com/go-humble/detect"
 	"github.com/gopherjs/gopherjs/js"
 	"honnef.co/go/js/dom"
-	"log"
-	"regexp"
 	"strings"
 )
 
@@ -35,6 +33,13 @@ func init() {
 // url and listens to changes via the "onhashchange" event.
 type Router struct {
 	routes []*route
+	// middleware holds the global middleware chain registered via Use.
+	// It runs, in order, ahead of any per-route middleware.
+	middleware []Middleware
+	// ctxMiddleware holds the global middleware chain registered via
+	// UseCtx. It runs, in order, ahead of any per-route ctxMiddleware, but
+	// after middleware (see chainCtx).
+	ctxMiddleware []MiddlewareCtx
 	// ShouldInterceptLinks tells the router whether or not to intercept click events
 	// on links and call the Navigate method instead of the default behavior.
 	// If it is set to true, the router will automatically intercept links when
@@ -43,6 +48,45 @@ type Router struct {
 	// listener is the js.Object representation of a listener callback.
 	// It is required in order to use the RemoveEventListener method
 	listener func(*js.Object)
+	// parent is set on routers returned by Subrouter, and nil for a router
+	// created with New. Routes registered on a subrouter are ultimately
+	// stored on the root router (see root and fullPath).
+	parent *Router
+	// prefix is prepended to every path registered through this router
+	// before it is handed to the parent router.
+	prefix string
+	// tree is the compiled route tree used by findBestRoute. It is only
+	// ever populated on the root router; see register in trie.go.
+	tree *node
+	// NotFoundHandler, if set, is invoked when no route matches the current
+	// path. It is called with a params map containing the attempted path
+	// under the "_path" key. If it is unset, the router logs a console
+	// warning instead.
+	NotFoundHandler Handler
+	// MethodNotAllowedHandler is reserved for when this router grows
+	// HTTP-method-style routing (GET/POST/etc. per path); it is unused
+	// today since routes are not distinguished by method.
+	MethodNotAllowedHandler Handler
+	// BeforeNavigate, if set, is called before every navigation with the
+	// router's current path and the path it is about to navigate to.
+	// Returning false cancels the transition: Navigate will not push a new
+	// history entry or set the hash, and since interceptLink calls
+	// event.PreventDefault() before handing off to Navigate, the browser
+	// doesn't follow the link either.
+	BeforeNavigate func(from, to string) bool
+	// AfterNavigate, if set, is called with the path every time pathChanged
+	// runs to completion, whether or not a route matched.
+	AfterNavigate func(path string)
+	// currentPath is the path the router last finished navigating to. It
+	// is used as the "from" argument to BeforeNavigate.
+	currentPath string
+	// skipNextHashGuard is set by Navigate right before it calls setHash in
+	// hash-fallback mode, since it has already run allowNavigate itself.
+	// watchHash's onhashchange listener consumes it to skip its own
+	// allowNavigate check for that one hash change, while still checking
+	// normally for hash changes it didn't originate (back/forward, the user
+	// editing the address bar).
+	skipNextHashGuard bool
 }
 
 // Handler is a function which is run in response to a specific
@@ -57,9 +101,14 @@ func New() *Router {
 }
 
 type route struct {
-	regex      *regexp.Regexp // Regex pattern that matches route
-	paramNames []string       // Ordered list of query parameters expected by route handler
-	handler    Handler        // Handler called when route is matched
+	name          string          // Optional name used to look up the route with Router.URL
+	path          string          // Original path template, e.g. "/users/{id}"
+	segments      []string        // path, split on "/" with empty segments removed
+	paramNames    []string        // Ordered list of path parameters expected by route handler
+	handler       Handler         // Handler called when route is matched, if registered via HandleFunc/Handle
+	ctxHandler    HandlerCtx      // Handler called when route is matched, if registered via HandleFuncCtx/HandleCtx
+	middleware    []Middleware    // Middleware applied to this route only, after the router's global middleware
+	ctxMiddleware []MiddlewareCtx // MiddlewareCtx applied to this route only, after the router's global ctxMiddleware
 }
 
 // HandleFunc will cause the router to call f whenever window.location.pathname
@@ -68,36 +117,63 @@ type route struct {
 // So, for example, a path argument of "users/{id}" will be triggered when the user
 // visits users/123 and will call the handler function with params["id"] = "123".
 func (r *Router) HandleFunc(path string, handler Handler) {
-	r.routes = append(r.routes, newRoute(path, handler))
+	r.Handle(path, handler)
 }
 
-// newRoute returns a route with the given arguments. paramNames and regex
-// are calculated from the path
+// defaultParamPattern is the regex used for a param such as "{id}" that
+// does not specify its own constraint.
+const defaultParamPattern = `[\w+-]*`
+
+// namedPatterns expands convenience shortcuts for common constraints so
+// callers can write "{id:int}" or "{id:uuid}" instead of spelling out the
+// underlying regex.
+var namedPatterns = map[string]string{
+	"int":  `[0-9]+`,
+	"uuid": `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// catchAllPattern is the sentinel constraint used by "{name:*}" to mean
+// "match every remaining path segment", rather than a literal regex.
+const catchAllPattern = `*`
+
+// parseParamToken splits a "{...}" token's inner contents into its param
+// name and the regex pattern it must match. A token of the form "name"
+// gets defaultParamPattern; "name:pattern" uses pattern verbatim unless it
+// is one of the namedPatterns shortcuts or the catchAllPattern sentinel.
+func parseParamToken(token string) (name, pattern string) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], defaultParamPattern
+	}
+	name, constraint := parts[0], parts[1]
+	if expanded, ok := namedPatterns[constraint]; ok {
+		return name, expanded
+	}
+	return name, constraint
+}
+
+// newRoute returns a route with the given arguments. segments and
+// paramNames are calculated from the path.
 func newRoute(path string, handler Handler) *route {
 	route := &route{
+		path:    path,
 		handler: handler,
 	}
-	strs := strings.Split(path, "/")
-	strs = removeEmptyStrings(strs)
-	pattern := `^`
-	for _, str := range strs {
-		if str[0] == '{' && str[len(str)-1] == '}' {
-			pattern += `/`
-			pattern += `([\w+-]*)`
-			route.paramNames = append(route.paramNames, str[1:(len(str)-1)])
-		} else {
-			pattern += `/`
-			pattern += str
+	route.segments = removeEmptyStrings(strings.Split(path, "/"))
+	for _, seg := range route.segments {
+		if len(seg) > 1 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+			name, _ := parseParamToken(seg[1 : len(seg)-1])
+			route.paramNames = append(route.paramNames, name)
 		}
 	}
-	pattern += `/?$`
-	route.regex = regexp.MustCompile(pattern)
 	return route
 }
 
 // Start causes the router to listen for changes to window.location and
-// trigger the appropriate handler whenever there is a change.
+// trigger the appropriate handler whenever there is a change. Start must
+// be called on the root router returned by New, not on a Subrouter.
 func (r *Router) Start() {
+	r.mustBeRoot("Start")
 	if browserSupportsPushState {
 		r.watchHistory()
 	} else {
@@ -110,8 +186,10 @@ func (r *Router) Start() {
 }
 
 // Stop causes the router to stop listening for changes, and therefore
-// the router will not trigger any more router.Handler functions.
+// the router will not trigger any more router.Handler functions. Stop
+// must be called on the root router returned by New, not on a Subrouter.
 func (r *Router) Stop() {
+	r.mustBeRoot("Stop")
 	if browserSupportsPushState {
 		js.Global.Set("onpopstate", nil)
 	} else {
@@ -123,11 +201,23 @@ func (r *Router) Stop() {
 // and update window.location accordingly. If the browser supports
 // history.pushState, that will be used. Otherwise, Navigate will
 // set the hash component of window.location to the given path.
+// If BeforeNavigate is set and returns false, Navigate does nothing.
+// Navigate must be called on the root router returned by New, not on a
+// Subrouter.
 func (r *Router) Navigate(path string) {
+	r.mustBeRoot("Navigate")
+	if !r.allowNavigate(path) {
+		return
+	}
 	if browserSupportsPushState {
 		pushState(path)
 		r.pathChanged(path)
 	} else {
+		// Setting the hash fires onhashchange asynchronously, which would
+		// otherwise call allowNavigate a second time via watchHash before
+		// dispatching pathChanged. skipNextHashGuard tells that listener
+		// this particular hash change has already been cleared.
+		r.skipNextHashGuard = true
 		setHash(path)
 	}
 	if r.ShouldInterceptLinks {
@@ -182,14 +272,15 @@ func (r *Router) InterceptLinks() {
 
 // interceptLink is intended to be used as a callback function. It stops
 // the default behavior of event and instead calls r.Navigate, passing through
-// the link's href property.
+// the link's href property (including any query string or fragment).
 func (r *Router) interceptLink(event dom.Event) {
-	path := event.CurrentTarget().GetAttribute("href")
+	href := event.CurrentTarget().GetAttribute("href")
+	path, _, _ := splitLocation(href)
 	// Only intercept the click event if we have a route which matches
 	// Otherwise, just do the default.
 	if bestRoute, _ := r.findBestRoute(path); bestRoute != nil {
 		event.PreventDefault()
-		go r.Navigate(path)
+		go r.Navigate(href)
 	}
 }
 
@@ -199,17 +290,24 @@ func (r *Router) setInitialHash() {
 	if getHash() == "" {
 		setHash("/")
 	} else {
-		r.pathChanged(getPathFromHash(getHash()))
+		path := getPathFromHash(getHash())
+		if r.allowNavigate(path) {
+			r.pathChanged(path)
+		}
 	}
 }
 
-// pathChanged should be called whenever the path changes and will trigger
-// the appropriate handler
-func (r *Router) pathChanged(path string) {
+// pathChanged should be called whenever the location changes and will
+// trigger the appropriate handler. raw is the full location, which may
+// include a "?query" string and/or a "#fragment" ahead of route matching.
+func (r *Router) pathChanged(raw string) {
+	path, query, fragment := splitLocation(raw)
+	r.currentPath = path
+	defer r.fireAfterNavigate(path)
 	bestRoute, tokens := r.findBestRoute(path)
-	// If no routes match, we throw console error and no handlers are called
+	// If no routes match, defer to NotFoundHandler instead of killing the app.
 	if bestRoute == nil {
-		log.Fatal("Could not find route to match: " + path)
+		r.notFound(path)
 		return
 	}
 	// Make the params map and pass it to the handler
@@ -217,28 +315,34 @@ func (r *Router) pathChanged(path string) {
 	for i, token := range tokens {
 		params[bestRoute.paramNames[i]] = token
 	}
-	bestRoute.handler(params)
+	if bestRoute.ctxHandler != nil {
+		r.chainCtx(bestRoute)(&RouteContext{
+			Params:   params,
+			Query:    parseQuery(query),
+			Fragment: fragment,
+			RawPath:  path,
+		})
+		return
+	}
+	r.chain(bestRoute)(params)
 }
 
-// Compare given path against regex patterns of routes. Preference given to routes
-// with most literal (non-query) matches. For example if we have the following:
+// findBestRoute walks the router's route tree (see trie.go) to find the
+// route matching path. Preference is given to the most specific match at
+// each level of the path: a literal segment beats a constrained param,
+// which beats an unconstrained param, which beats a catch-all. For example
+// if we have the following:
 //   Route 1: /todos/work
 //   Route 2: /todos/{category}
 // And the path argument is "/todos/work", the bestRoute would be todos/work
 // because the string "work" matches the literal in Route 1.
 func (r Router) findBestRoute(path string) (bestRoute *route, tokens []string) {
-	leastParams := -1
-	for _, route := range r.routes {
-		matches := route.regex.FindStringSubmatch(path)
-		if matches != nil {
-			if (leastParams == -1) || (len(matches) < leastParams) {
-				leastParams = len(matches)
-				bestRoute = route
-				tokens = matches[1:]
-			}
-		}
+	root := r.root()
+	if root.tree == nil {
+		return nil, nil
 	}
-	return bestRoute, tokens
+	segments := removeEmptyStrings(strings.Split(path, "/"))
+	return root.tree.match(segments, make([]string, 0, len(segments)))
 }
 
 // removeEmptyStrings removes any empty strings from strings
@@ -258,6 +362,11 @@ func (r *Router) watchHash() {
 	js.Global.Set("onhashchange", func() {
 		go func() {
 			path := getPathFromHash(getHash())
+			if r.skipNextHashGuard {
+				r.skipNextHashGuard = false
+			} else if !r.allowNavigate(path) {
+				return
+			}
 			r.pathChanged(path)
 		}()
 	})
@@ -268,7 +377,12 @@ func (r *Router) watchHash() {
 func (r *Router) watchHistory() {
 	js.Global.Set("onpopstate", func() {
 		go func() {
-			r.pathChanged(getPath())
+			// Since history.pushState routes on the pathname, location.hash is a
+			// genuine URL fragment here, unlike in the hash-routing fallback.
+			raw := getPath() + getSearch() + getHash()
+			if r.allowNavigate(raw) {
+				r.pathChanged(raw)
+			}
 			if r.ShouldInterceptLinks {
 				r.InterceptLinks()
 			}
@@ -286,6 +400,11 @@ func getHash() string {
 	return js.Global.Get("location").Get("hash").String()
 }
 
+// getSearch is an alias for js.Global.Get("location").Get("search").String()
+func getSearch() string {
+	return js.Global.Get("location").Get("search").String()
+}
+
 // setHash is an alias for js.Global.Get("location").Set("hash", hash)
 func setHash(hash string) {
 	js.Global.Get("location").Set("hash", hash)
@@ -0,0 +1,90 @@
+package router
+
+import "strings"
+
+// Subrouter returns a new Router that registers all of its routes on r,
+// with prefix prepended to every path. Middleware added to the subrouter
+// via Use applies only to routes registered through it, running after any
+// middleware registered on r (or, transitively, on r's own ancestors).
+// Since browserSupportsPushState is shared package state, a subrouter
+// behaves identically to r with respect to pushState vs. hash routing;
+// only the root router returned by New needs to be passed to Start.
+//
+// Example:
+//
+//	admin := r.Subrouter("/admin")
+//	admin.Use(RequireAdmin)
+//	admin.HandleFunc("/users", listUsers)
+func (r *Router) Subrouter(prefix string) *Router {
+	return &Router{
+		parent: r,
+		prefix: prefix,
+	}
+}
+
+// root walks up the parent chain and returns the router that routes are
+// ultimately stored on and dispatched from.
+func (r *Router) root() *Router {
+	root := r
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// fullPath prepends r's prefix (and that of every ancestor) to path.
+func (r *Router) fullPath(path string) string {
+	if r.parent == nil {
+		return path
+	}
+	return r.parent.fullPath(joinPath(r.prefix, path))
+}
+
+// ancestorMiddleware returns the middleware registered on r's ancestors
+// (via Use), in root-to-leaf order, followed by r's own middleware. It
+// does not include the root's middleware, since that is already applied
+// automatically by chain when dispatching on the root router.
+func (r *Router) ancestorMiddleware() []Middleware {
+	if r.parent == nil {
+		return nil
+	}
+	return append(r.parent.ancestorMiddleware(), r.middleware...)
+}
+
+// ancestorCtxMiddleware is the MiddlewareCtx analogue of ancestorMiddleware,
+// for routes registered with HandleCtx/HandleFuncCtx. At each ancestor level
+// it includes both that level's UseCtx and its plain Use middleware, adapted
+// with asCtxMiddleware, so a subrouter's Use guards its HandlerCtx routes
+// just like it guards its Handler routes.
+func (r *Router) ancestorCtxMiddleware() []MiddlewareCtx {
+	if r.parent == nil {
+		return nil
+	}
+	result := r.parent.ancestorCtxMiddleware()
+	for _, mw := range r.middleware {
+		result = append(result, asCtxMiddleware(mw))
+	}
+	return append(result, r.ctxMiddleware...)
+}
+
+// mustBeRoot panics if r is a Subrouter rather than the root router
+// returned by New. Start, Stop, and Navigate all assume they're driving
+// the root: ancestorMiddleware and ancestorCtxMiddleware already bake a
+// subrouter's own middleware into every route it registers, on the
+// assumption that only the root's middleware is applied again by chain/
+// chainCtx at dispatch time. Calling these methods on a subrouter would
+// apply that subrouter's middleware twice.
+func (r *Router) mustBeRoot(method string) {
+	if r.parent != nil {
+		panic("router: " + method + " must be called on the root router returned by New, not a Subrouter")
+	}
+}
+
+// joinPath joins a subrouter prefix and a route path into a single path,
+// ensuring exactly one "/" separates them.
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	return strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(path, "/")
+}
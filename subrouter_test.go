@@ -0,0 +1,62 @@
+package router
+
+import "testing"
+
+// TestSubrouterNavigatePanics pins that Navigate (and, by the same guard,
+// Start/Stop) refuses to run on a Subrouter, since a subrouter's own
+// middleware is already baked into every route it registers and would be
+// applied a second time by chain/chainCtx if dispatched from there.
+func TestSubrouterNavigatePanics(t *testing.T) {
+	r := New()
+	sub := r.Subrouter("/admin")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Navigate on a Subrouter to panic")
+		}
+	}()
+	sub.Navigate("/admin/users")
+}
+
+// TestMiddlewareOrdering pins the composition order across the three
+// places middleware can be registered: global (Use on the root), a
+// subrouter's own Use, and per-route middleware passed to Handle.
+func TestMiddlewareOrdering(t *testing.T) {
+	r := New()
+	var order []string
+	track := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(params map[string]string) {
+				order = append(order, name)
+				next(params)
+			}
+		}
+	}
+
+	r.Use(track("global"))
+	admin := r.Subrouter("/admin")
+	admin.Use(track("admin"))
+	admin.Handle("/users", func(params map[string]string) {
+		order = append(order, "handler")
+	}, track("route"))
+
+	route, tokens := r.findBestRoute("/admin/users")
+	if route == nil {
+		t.Fatal("expected /admin/users to match")
+	}
+	params := map[string]string{}
+	for i, tok := range tokens {
+		params[route.paramNames[i]] = tok
+	}
+	r.chain(route)(params)
+
+	want := []string{"global", "admin", "route", "handler"}
+	if len(order) != len(want) {
+		t.Fatalf("got order %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got order %v, want %v", order, want)
+		}
+	}
+}
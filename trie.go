@@ -0,0 +1,127 @@
+package router
+
+import (
+	"regexp"
+	"strings"
+)
+
+// node is one segment of the router's compiled route tree. Matching a path
+// walks the tree once, one segment per level, instead of running every
+// route's regex against the whole path. At each level, a node's children
+// are tried in priority order (static, then regex, then param, then
+// catch-all), which gives a deterministic specificity ordering without
+// needing to compare routes against each other.
+type node struct {
+	route    *route  // set when a registered route's path ends at this node
+	static   []*node // children matched by exact literal segment text
+	params   []*node // children matched by any segment, e.g. "{id}"
+	regexes  []*node // children matched by a constrained segment, e.g. "{id:[0-9]+}"
+	catchAll *node   // child matched by every remaining segment, e.g. "{rest:*}"
+
+	segment string         // literal text to match, for a node in static
+	name    string         // param name, for a node in params, regexes, or catchAll
+	pattern *regexp.Regexp // compiled constraint, for a node in regexes
+}
+
+// insert adds rt to the tree, creating whatever intermediate nodes are
+// needed for the given path segments.
+func (n *node) insert(segments []string, rt *route) {
+	if len(segments) == 0 {
+		n.route = rt
+		return
+	}
+	n.childFor(segments[0]).insert(segments[1:], rt)
+}
+
+// childFor returns the child of n that should hold seg, creating it first
+// if this is the first route to use that segment shape.
+func (n *node) childFor(seg string) *node {
+	if len(seg) > 1 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+		name, pattern := parseParamToken(seg[1 : len(seg)-1])
+		switch pattern {
+		case catchAllPattern:
+			if n.catchAll == nil {
+				n.catchAll = &node{name: name}
+			}
+			return n.catchAll
+		case defaultParamPattern:
+			for _, c := range n.params {
+				if c.name == name {
+					return c
+				}
+			}
+			c := &node{name: name}
+			n.params = append(n.params, c)
+			return c
+		default:
+			anchored := `^` + pattern + `$`
+			for _, c := range n.regexes {
+				if c.name == name && c.pattern.String() == anchored {
+					return c
+				}
+			}
+			c := &node{name: name, pattern: regexp.MustCompile(anchored)}
+			n.regexes = append(n.regexes, c)
+			return c
+		}
+	}
+	for _, c := range n.static {
+		if c.segment == seg {
+			return c
+		}
+	}
+	c := &node{segment: seg}
+	n.static = append(n.static, c)
+	return c
+}
+
+// match walks the tree looking for a route matching segments, appending
+// each param value it passes through to params in left-to-right order.
+// It returns the first matching route found, trying static children ahead
+// of regex children ahead of param children ahead of a catch-all at every
+// level, so a constrained "{id:[0-9]+}" wins over a co-located
+// unconstrained "{name}", and nil if nothing matches.
+func (n *node) match(segments []string, params []string) (*route, []string) {
+	if len(segments) == 0 {
+		if n.route != nil {
+			return n.route, params
+		}
+		return nil, nil
+	}
+	seg := segments[0]
+	rest := segments[1:]
+	for _, c := range n.static {
+		if c.segment == seg {
+			if rt, ps := c.match(rest, params); rt != nil {
+				return rt, ps
+			}
+		}
+	}
+	for _, c := range n.regexes {
+		if c.pattern.MatchString(seg) {
+			if rt, ps := c.match(rest, append(params, seg)); rt != nil {
+				return rt, ps
+			}
+		}
+	}
+	for _, c := range n.params {
+		if rt, ps := c.match(rest, append(params, seg)); rt != nil {
+			return rt, ps
+		}
+	}
+	if n.catchAll != nil && n.catchAll.route != nil {
+		return n.catchAll.route, append(params, strings.Join(segments, "/"))
+	}
+	return nil, nil
+}
+
+// register appends rt to the root router's routes and inserts it into the
+// root router's route tree, creating the tree if this is the first route.
+func (r *Router) register(rt *route) {
+	root := r.root()
+	root.routes = append(root.routes, rt)
+	if root.tree == nil {
+		root.tree = &node{}
+	}
+	root.tree.insert(rt.segments, rt)
+}
@@ -0,0 +1,56 @@
+package router
+
+import "testing"
+
+// TestFindBestRoutePriority pins the specificity ordering documented on
+// findBestRoute: static beats regex beats unconstrained param beats
+// catch-all, evaluated at each level of the tree independently.
+func TestFindBestRoutePriority(t *testing.T) {
+	noop := func(map[string]string) {}
+
+	cases := []struct {
+		name      string
+		routes    []string
+		path      string
+		wantParam string // paramNames[len-1] of the expected winner, "" for a static route
+	}{
+		{
+			name:      "static beats regex",
+			routes:    []string{"/users/{name:[a-z]+}", "/users/me"},
+			path:      "/users/me",
+			wantParam: "",
+		},
+		{
+			name:      "regex beats unconstrained param",
+			routes:    []string{"/users/{name}", "/users/{id:[0-9]+}"},
+			path:      "/users/42",
+			wantParam: "id",
+		},
+		{
+			name:      "unconstrained param beats catch-all",
+			routes:    []string{"/files/{rest:*}", "/files/{name}"},
+			path:      "/files/report",
+			wantParam: "name",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := New()
+			for _, path := range c.routes {
+				r.HandleFunc(path, noop)
+			}
+			route, _ := r.findBestRoute(c.path)
+			if route == nil {
+				t.Fatalf("expected a route to match %s", c.path)
+			}
+			got := ""
+			if len(route.paramNames) > 0 {
+				got = route.paramNames[len(route.paramNames)-1]
+			}
+			if got != c.wantParam {
+				t.Fatalf("winning route param = %q, want %q", got, c.wantParam)
+			}
+		})
+	}
+}